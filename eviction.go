@@ -0,0 +1,72 @@
+package pool
+
+import "time"
+
+type (
+	//空闲资源淘汰策略
+	EvictionPolicy interface {
+		//判断该空闲资源是否应被淘汰
+		ShouldEvict(src Src, createdAt, lastUsedAt time.Time, stats PoolStats) bool
+	}
+	//资源出入池的顺序
+	Order int
+
+	maxIdleCountPolicy struct{ n int }
+	idleTimeoutPolicy  struct{ d time.Duration }
+	maxLifetimePolicy  struct{ d time.Duration }
+)
+
+const (
+	//后进先出, 最近归还的资源优先被取用
+	LIFO Order = iota
+	//先进先出, 最早归还的资源优先被取用
+	FIFO
+)
+
+//MaxIdleCount... 空闲数量超过n的部分会被淘汰, 即ClassicPool原有的maxIdle行为
+func MaxIdleCount(n int) EvictionPolicy {
+	return maxIdleCountPolicy{n: n}
+}
+
+func (p maxIdleCountPolicy) ShouldEvict(src Src, createdAt, lastUsedAt time.Time, stats PoolStats) bool {
+	return stats.Idle > p.n
+}
+
+//IdleTimeout... 空闲超过d的资源会被淘汰
+func IdleTimeout(d time.Duration) EvictionPolicy {
+	return idleTimeoutPolicy{d: d}
+}
+
+func (p idleTimeoutPolicy) ShouldEvict(src Src, createdAt, lastUsedAt time.Time, stats PoolStats) bool {
+	return time.Since(lastUsedAt) > p.d
+}
+
+//MaxLifetime... 创建超过d的空闲资源会被淘汰, 与其空闲时长无关; 该策略只作用于gc巡检的空闲资源, 归还时不检查
+func MaxLifetime(d time.Duration) EvictionPolicy {
+	return maxLifetimePolicy{d: d}
+}
+
+func (p maxLifetimePolicy) ShouldEvict(src Src, createdAt, lastUsedAt time.Time, stats PoolStats) bool {
+	return time.Since(createdAt) > p.d
+}
+
+//WithEviction... 设置空闲资源淘汰策略, 默认为MaxIdleCount(maxIdle)
+func WithEviction(policy EvictionPolicy) Option {
+	return func(cfg *poolConfig) {
+		cfg.eviction = policy
+	}
+}
+
+//WithMaxLifetime... 设置资源的最大存活时间, 超过后即使正在被使用也会在归还时被淘汰, 0表示不限制
+func WithMaxLifetime(d time.Duration) Option {
+	return func(cfg *poolConfig) {
+		cfg.maxLifetime = d
+	}
+}
+
+//WithOrder... 设置空闲资源的取用顺序, 默认LIFO
+func WithOrder(order Order) Option {
+	return func(cfg *poolConfig) {
+		cfg.order = order
+	}
+}
@@ -0,0 +1,252 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	//基于函数处理的资源池, 每个资源绑定一个常驻worker协程消费任务
+	FuncPool interface {
+		//提交任务, 不等待执行结果
+		Submit(arg interface{}) error
+		//提交任务并等待执行结果
+		SubmitWait(ctx context.Context, arg interface{}) (interface{}, error)
+		//销毁资源池
+		Release()
+		//返回当前资源池中资源数量
+		Len() int
+	}
+	//处理函数, 同一个worker复用同一个Src执行多个任务
+	Handler func(src Src, arg interface{}) (interface{}, error)
+	//资源池[绑定函数]
+	funcPool struct {
+		workers    chan *worker //空闲worker
+		allWorkers []*worker    //全部存活的worker, 含空闲和正在处理任务的
+		capacity   int
+		maxIdle    int
+		len        int
+		factory    Factory
+		handler    Handler
+		gctime     time.Duration //空闲超过该时长的worker会被gc回收
+		released   bool
+		sync.RWMutex
+	}
+	//一个Src及其常驻的任务消费协程
+	worker struct {
+		src      Src
+		tasks    chan *task
+		lastUsed time.Time
+	}
+	//一次任务提交
+	task struct {
+		arg    interface{}
+		respCh chan taskResult
+	}
+	//任务执行结果
+	taskResult struct {
+		result interface{}
+		err    error
+	}
+)
+
+//NewFuncPool... 构建绑定处理函数的资源池
+func NewFuncPool(capacity, maxIdle int, factory Factory, handler Handler, opts ...Option) FuncPool {
+	cfg := newPoolConfig(opts...)
+	pool := &funcPool{
+		workers:  make(chan *worker, capacity),
+		capacity: capacity,
+		maxIdle:  maxIdle,
+		factory:  factory,
+		handler:  handler,
+		gctime:   cfg.gctime,
+		released: false,
+	}
+	go pool.gc()
+	return pool
+}
+
+//提交任务, 不等待执行结果
+func (self *funcPool) Submit(arg interface{}) error {
+	w, err := self.acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	w.tasks <- &task{arg: arg}
+	return nil
+}
+
+//提交任务并等待执行结果
+func (self *funcPool) SubmitWait(ctx context.Context, arg interface{}) (interface{}, error) {
+	w, err := self.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t := &task{arg: arg, respCh: make(chan taskResult, 1)}
+	w.tasks <- t
+	select {
+	case r := <-t.respCh:
+		return r.result, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//获取一个空闲worker, 不存在则按容量创建, 容量已满时等待worker被归还
+func (self *funcPool) acquire(ctx context.Context) (*worker, error) {
+	self.RLock()
+	if self.released {
+		self.RUnlock()
+		return nil, releasedError
+	}
+	select {
+	case w, ok := <-self.workers:
+		self.RUnlock()
+		if !ok {
+			return nil, releasedError
+		}
+		return w, nil
+	default:
+		self.RUnlock()
+	}
+	w, err := self.tryCreate()
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		return w, nil
+	}
+	select {
+	case w, ok := <-self.workers:
+		if !ok {
+			return nil, releasedError
+		}
+		return w, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//worker执行完任务后归还
+func (self *funcPool) recycle(w *worker) {
+	w.lastUsed = time.Now()
+	self.RLock()
+	defer self.RUnlock()
+	if self.released {
+		return
+	}
+	self.workers <- w
+}
+
+//worker常驻消费协程, tasks被关闭后退出并自毁所绑定的资源
+func (self *funcPool) run(w *worker) {
+	for t := range w.tasks {
+		result, err := self.handler(w.src, t.arg)
+		if t.respCh != nil {
+			t.respCh <- taskResult{result: result, err: err}
+		}
+		self.recycle(w)
+	}
+	w.src.Release()
+}
+
+//容量未满时创建一个新的worker, 已满则返回(nil, nil)交由调用方等待
+func (self *funcPool) tryCreate() (*worker, error) {
+	self.Lock()
+	if self.released {
+		self.Unlock()
+		return nil, releasedError
+	}
+	if self.len >= self.capacity {
+		self.Unlock()
+		return nil, nil
+	}
+	src, err := self.factory()
+	if err != nil {
+		self.Unlock()
+		return nil, err
+	}
+	self.len++
+	w := &worker{src: src, tasks: make(chan *task, 1), lastUsed: time.Now()}
+	self.allWorkers = append(self.allWorkers, w)
+	self.Unlock()
+	go self.run(w)
+	return w, nil
+}
+
+//销毁资源池, 关闭全部worker(含正在处理任务的)的任务channel, 使其处理完当前任务后自毁资源
+func (self *funcPool) Release() {
+	self.Lock()
+	if self.released {
+		self.Unlock()
+		return
+	}
+	self.released = true
+	workers := self.allWorkers
+	self.allWorkers = nil
+	self.len = 0
+	close(self.workers)
+	self.Unlock()
+	for _, w := range workers {
+		close(w.tasks)
+	}
+}
+
+//返回当前资源池中资源数量
+func (self *funcPool) Len() int {
+	self.RLock()
+	defer self.RUnlock()
+	return self.len
+}
+
+// 空闲worker回收协程: 空闲超过gctime, 或空闲数量超过maxIdle的部分会被回收
+func (self *funcPool) gc() {
+	for !self.isReleased() {
+		self.Lock()
+		var drained []*worker
+	drain:
+		for {
+			select {
+			case w := <-self.workers:
+				drained = append(drained, w)
+			default:
+				break drain
+			}
+		}
+		survivors := make([]*worker, 0, len(drained))
+		for _, w := range drained {
+			if time.Since(w.lastUsed) > self.gctime || len(survivors) >= self.maxIdle {
+				close(w.tasks)
+				self.removeWorker(w)
+			} else {
+				survivors = append(survivors, w)
+			}
+		}
+		for _, w := range survivors {
+			self.workers <- w
+		}
+		self.Unlock()
+		time.Sleep(self.gctime)
+	}
+}
+
+//从allWorkers中摘除一个worker并扣减计数, 调用方需已持有锁
+func (self *funcPool) removeWorker(w *worker) {
+	for i, v := range self.allWorkers {
+		if v == w {
+			last := len(self.allWorkers) - 1
+			self.allWorkers[i] = self.allWorkers[last]
+			self.allWorkers = self.allWorkers[:last]
+			break
+		}
+	}
+	self.len--
+}
+
+//资源池是否已释放
+func (self *funcPool) isReleased() bool {
+	self.RLock()
+	defer self.RUnlock()
+	return self.released
+}
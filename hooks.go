@@ -0,0 +1,164 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	//资源池的可观测性回调, 各字段均可为nil表示不关心该事件
+	Hooks struct {
+		//成功获取到资源后触发, waited为本次等待的时长
+		OnAcquire func(waited time.Duration)
+		//资源被归还后触发
+		OnRelease func()
+		//创建一个新资源后触发, err非nil表示创建失败
+		OnCreate func(src Src, err error)
+		//销毁一个资源后触发
+		OnDestroy func(src Src, err error)
+		//淘汰一个资源后触发(含空闲资源, 以及归还时超过maxLifetime的资源), reason说明淘汰原因
+		OnEvict func(reason string)
+		//Call/CallContext中callback发生panic时触发
+		OnCallPanic func(recovered interface{})
+	}
+	//单个等待耗时区间的统计
+	HistogramBucket struct {
+		Le    time.Duration //该桶的等待耗时上界
+		Count uint64        //等待时长<=Le的累计次数
+	}
+)
+
+//默认的获取资源等待耗时分桶边界
+var defaultWaitBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+//WithHooks... 设置资源池的可观测性回调
+func WithHooks(hooks Hooks) Option {
+	return func(cfg *poolConfig) {
+		cfg.hooks = hooks
+	}
+}
+
+//记录一次获取资源的等待耗时, +1对应defaultWaitBuckets之外的溢出桶
+func (self *classic) recordWait(d time.Duration) {
+	for i, le := range defaultWaitBuckets {
+		if d <= le {
+			atomic.AddUint64(&self.waitHistogram[i], 1)
+			return
+		}
+	}
+	atomic.AddUint64(&self.waitHistogram[len(defaultWaitBuckets)], 1)
+}
+
+//Stats... 返回资源池当前的运行状态
+func (self *classic) Stats() PoolStats {
+	self.RLock()
+	idle := len(self.idle)
+	length := self.len
+	self.RUnlock()
+	waiters := atomic.LoadInt32(&self.waiting)
+	histogram := make([]HistogramBucket, len(defaultWaitBuckets)+1)
+	var cumulative uint64
+	for i, le := range defaultWaitBuckets {
+		cumulative += atomic.LoadUint64(&self.waitHistogram[i])
+		histogram[i] = HistogramBucket{Le: le, Count: cumulative}
+	}
+	cumulative += atomic.LoadUint64(&self.waitHistogram[len(defaultWaitBuckets)])
+	histogram[len(defaultWaitBuckets)] = HistogramBucket{Le: -1, Count: cumulative}
+	return PoolStats{
+		Len:                  length,
+		Idle:                 idle,
+		InUse:                length - idle,
+		Waiters:              waiters,
+		TotalCreated:         atomic.LoadUint64(&self.created),
+		TotalDestroyed:       atomic.LoadUint64(&self.destroyed),
+		AcquireWaitHistogram: histogram,
+	}
+}
+
+//HealthCheck... 巡检空闲资源, 淘汰不可用的部分, 返回被淘汰的数量
+func (self *classic) HealthCheck(ctx context.Context) (int, error) {
+	self.Lock()
+	snapshot := make([]*pooledSrc, len(self.idle))
+	copy(snapshot, self.idle)
+	self.Unlock()
+	bad := make(map[Src]bool, len(snapshot))
+	for _, p := range snapshot {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		default:
+		}
+		if !p.src.IsUsable() {
+			bad[p.src] = true
+		}
+	}
+	if len(bad) == 0 {
+		return 0, nil
+	}
+	self.Lock()
+	survivors := self.idle[:0:0]
+	var destroyed []*pooledSrc
+	for _, p := range self.idle {
+		if bad[p.src] {
+			p.src.Release()
+			atomic.AddUint64(&self.destroyed, 1)
+			delete(self.createdAt, p.src)
+			self.len--
+			destroyed = append(destroyed, p)
+		} else {
+			survivors = append(survivors, p)
+		}
+	}
+	self.idle = survivors
+	self.Unlock()
+	for _, p := range destroyed {
+		self.fireOnDestroy(p.src, nil)
+		self.fireOnEvict("unusable")
+	}
+	return len(destroyed), nil
+}
+
+func (self *classic) fireOnAcquire(waited time.Duration) {
+	if self.hooks.OnAcquire != nil {
+		self.hooks.OnAcquire(waited)
+	}
+}
+
+func (self *classic) fireOnRelease() {
+	if self.hooks.OnRelease != nil {
+		self.hooks.OnRelease()
+	}
+}
+
+func (self *classic) fireOnCreate(src Src, err error) {
+	if self.hooks.OnCreate != nil {
+		self.hooks.OnCreate(src, err)
+	}
+}
+
+func (self *classic) fireOnDestroy(src Src, err error) {
+	if self.hooks.OnDestroy != nil {
+		self.hooks.OnDestroy(src, err)
+	}
+}
+
+func (self *classic) fireOnEvict(reason string) {
+	if self.hooks.OnEvict != nil {
+		self.hooks.OnEvict(reason)
+	}
+}
+
+func (self *classic) fireOnCallPanic(recovered interface{}) {
+	if self.hooks.OnCallPanic != nil {
+		self.hooks.OnCallPanic(recovered)
+	}
+}
@@ -0,0 +1,53 @@
+package pool
+
+import (
+	"context"
+	"errors"
+)
+
+//并发数限制器, 不依赖Factory/Src, 只用于控制同时运行的协程数量
+type Limiter struct {
+	tokens chan struct{}
+}
+
+//ErrOverReturn Return被调用的次数超过了Borrow/TryBorrow成功的次数
+var ErrOverReturn = errors.New("pool: limiter被重复归还")
+
+//NewLimiter... 构建一个最多允许n个并发的限制器
+func NewLimiter(n int) *Limiter {
+	l := &Limiter{tokens: make(chan struct{}, n)}
+	for i := 0; i < n; i++ {
+		l.tokens <- struct{}{}
+	}
+	return l
+}
+
+//Borrow... 占用一个名额, 在ctx被取消前会一直等待
+func (self *Limiter) Borrow(ctx context.Context) error {
+	select {
+	case <-self.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//TryBorrow... 尝试占用一个名额, 没有空闲名额时立即返回false
+func (self *Limiter) TryBorrow() bool {
+	select {
+	case <-self.tokens:
+		return true
+	default:
+		return false
+	}
+}
+
+//Return... 归还一个名额, 重复归还会返回ErrOverReturn
+func (self *Limiter) Return() error {
+	select {
+	case self.tokens <- struct{}{}:
+		return nil
+	default:
+		return ErrOverReturn
+	}
+}
@@ -1,10 +1,11 @@
 package pool
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,22 +14,51 @@ type (
 	Pool interface {
 		//调用资源池中的资源
 		Call(func(Src) error) error
+		//带超时/取消的资源调用
+		CallContext(ctx context.Context, fn func(Src) error) error
+		//获取一个可用资源, 需配合Put归还
+		Acquire(ctx context.Context) (Src, error)
+		//归还Acquire取出的资源
+		Put(src Src) error
 		//销毁资源池
 		Release()
 		//返回当前资源池中资源数量
 		Len() int
+		//返回资源池当前的运行状态
+		Stats() PoolStats
+		//巡检空闲资源, 淘汰不可用的部分, 返回被淘汰的数量
+		HealthCheck(ctx context.Context) (int, error)
 	}
 	//经典资源池
 	classic struct {
-		srcs     chan Src      //资源列表(Src必须为指针类型)
-		capacity int           //资源池容量
-		maxIdle  int           //资源最大空闲数
-		len      int           //资源数量
-		factory  Factory       //创建资源的方法
-		gctime   time.Duration //空闲资源回收时间
-		released bool          //标记是否已经关闭资源池
+		idle           []*pooledSrc        //空闲资源, 按入池顺序排列
+		createdAt      map[Src]time.Time   //资源的创建时间, 覆盖空闲和使用中的资源
+		notify         chan struct{}       //有资源被归还/创建时关闭并替换, 用于唤醒所有等待者
+		capacity       int                 //资源池容量
+		maxIdle        int                 //资源最大空闲数
+		len            int                 //资源数量
+		factory        Factory             //创建资源的方法
+		gctime         time.Duration       //空闲资源回收时间
+		acquireTimeout time.Duration       //等待资源的超时时间, 0表示不超时
+		maxBlocking    int32               //允许同时等待资源的协程数上限, 0表示不限制
+		blocking       int32               //当前因maxBlocking限制被计数的等待协程数
+		waiting        int32               //当前在blocking select中等待资源的协程数, 无论是否设置maxBlocking都会计数
+		eviction       EvictionPolicy      //空闲资源淘汰策略
+		maxLifetime    time.Duration       //资源最大存活时间, 0表示不限制
+		order          Order               //空闲资源的取用顺序
+		hooks          Hooks               //可观测性回调
+		waitHistogram  []uint64            //获取资源等待耗时分桶计数, 与defaultWaitBuckets对应, 末位为溢出桶
+		created        uint64              //累计创建的资源数
+		destroyed      uint64              //累计销毁的资源数
+		released       bool                //标记是否已经关闭资源池
 		sync.RWMutex
 	}
+	//空闲队列中的一个资源
+	pooledSrc struct {
+		src        Src
+		createdAt  time.Time
+		lastUsedAt time.Time
+	}
 	//资源接口
 	Src interface {
 		//判断资源是否可用
@@ -40,64 +70,237 @@ type (
 	}
 	//创建资源的方法
 	Factory func() (Src, error)
+	//资源池配置项
+	Option func(*poolConfig)
+	//各类资源池共用的可配置项
+	poolConfig struct {
+		gctime         time.Duration  //空闲资源回收时间
+		acquireTimeout time.Duration  //等待资源的超时时间, 0表示不超时
+		maxBlocking    int32          //允许同时等待资源的协程数上限, 0表示不限制
+		eviction       EvictionPolicy //空闲资源淘汰策略, 默认MaxIdleCount(maxIdle)
+		maxLifetime    time.Duration  //资源最大存活时间, 0表示不限制
+		order          Order          //空闲资源的取用顺序, 默认LIFO
+		hooks          Hooks          //可观测性回调
+		shardCount     int            //ShardedPool的分片数量, 默认runtime.GOMAXPROCS(0)
+	}
+	//资源池运行状态
+	PoolStats struct {
+		Len                  int               //资源总数(使用中+空闲)
+		Idle                 int               //空闲资源数
+		InUse                int               //使用中的资源数
+		Waiters              int32             //当前等待资源的协程数
+		TotalCreated         uint64            //累计创建的资源数
+		TotalDestroyed       uint64            //累计销毁的资源数
+		AcquireWaitHistogram []HistogramBucket //获取资源的等待耗时分布, 按桶上界从小到大累计计数, 末位为不设上界的溢出桶(Le为-1)
+	}
 )
 
 const GC_TIME = 60e9
 
-var releasedError = errors.New("资源池已关闭")
+var (
+	releasedError = errors.New("资源池已关闭")
+	//ErrAcquireTimeout 等待资源超过WithAcquireTimeout设置的时长
+	ErrAcquireTimeout = errors.New("pool: 等待资源超时")
+	//ErrPoolOverloaded 等待资源的协程数超过WithMaxBlocking设置的上限
+	ErrPoolOverloaded = errors.New("pool: 等待资源的协程数已达上限")
+)
+
+//WithGCTime... 设置空闲资源回收周期, 默认GC_TIME
+func WithGCTime(gctime time.Duration) Option {
+	return func(cfg *poolConfig) {
+		cfg.gctime = gctime
+	}
+}
+
+//WithAcquireTimeout... 设置等待资源的超时时间, 超时后返回ErrAcquireTimeout
+func WithAcquireTimeout(timeout time.Duration) Option {
+	return func(cfg *poolConfig) {
+		cfg.acquireTimeout = timeout
+	}
+}
+
+//WithMaxBlocking... 限制同时等待资源的协程数, 超出的请求立即返回ErrPoolOverloaded
+func WithMaxBlocking(n int) Option {
+	return func(cfg *poolConfig) {
+		cfg.maxBlocking = int32(n)
+	}
+}
+
+//newPoolConfig... 应用配置项, 返回带默认值的poolConfig
+func newPoolConfig(opts ...Option) poolConfig {
+	cfg := poolConfig{gctime: GC_TIME}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
 
 //ClassicPool... 构建经典资源池
-func ClassicPool(capacity, maxIdle int, factory Factory, gctime ...time.Duration) Pool {
-	if len(gctime) == 0 {
-		gctime = append(gctime, GC_TIME)
+func ClassicPool(capacity, maxIdle int, factory Factory, opts ...Option) Pool {
+	cfg := newPoolConfig(opts...)
+	eviction := cfg.eviction
+	if eviction == nil {
+		eviction = MaxIdleCount(maxIdle)
 	}
 	pool := &classic{
-		srcs:     make(chan Src, capacity),
-		capacity: capacity,
-		maxIdle:  maxIdle,
-		factory:  factory,
-		gctime:   gctime[0],
-		released: false,
+		createdAt:      make(map[Src]time.Time),
+		notify:         make(chan struct{}),
+		waitHistogram:  make([]uint64, len(defaultWaitBuckets)+1),
+		capacity:       capacity,
+		maxIdle:        maxIdle,
+		factory:        factory,
+		gctime:         cfg.gctime,
+		acquireTimeout: cfg.acquireTimeout,
+		maxBlocking:    cfg.maxBlocking,
+		eviction:       eviction,
+		maxLifetime:    cfg.maxLifetime,
+		order:          cfg.order,
+		hooks:          cfg.hooks,
+		released:       false,
 	}
 	go pool.gc()
 	return pool
 }
 
 //调用资源池中的资源
-func (self *classic) Call(callback func(Src) error) (err error) {
-	var src Src
-wait:
-	self.RLock()
-	if self.released {
-		self.RUnlock()
-		return releasedError
-	}
-	select {
-	case src = <-self.srcs:
-		self.RUnlock()
-		if !src.IsUsable() {
-			self.del(src)
-			goto wait
-		}
-	default:
-		self.RUnlock()
-		err = self.incAuto()
-		if err != nil {
-			return err
-		}
-		runtime.Gosched()
-		goto wait
+func (self *classic) Call(callback func(Src) error) error {
+	return self.CallContext(context.Background(), callback)
+}
+
+//带超时/取消的资源调用
+func (self *classic) CallContext(ctx context.Context, callback func(Src) error) (err error) {
+	src, err := self.Acquire(ctx)
+	if err != nil {
+		return err
 	}
 	defer func() {
 		if p := recover(); p != nil {
+			self.fireOnCallPanic(p)
 			err = fmt.Errorf("%v", p)
 		}
-		self.recover(src)
+		self.Put(src)
 	}()
 	err = callback(src)
 	return err
 }
 
+//获取一个可用资源, 需配合Put归还
+func (self *classic) Acquire(ctx context.Context) (Src, error) {
+	start := time.Now()
+	var timeout <-chan time.Time
+	if self.acquireTimeout > 0 {
+		timer := time.NewTimer(self.acquireTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		src, err, ok := self.tryPop()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if !src.IsUsable() {
+				self.del(src)
+				self.fireOnEvict("unusable")
+				continue
+			}
+			waited := time.Since(start)
+			self.recordWait(waited)
+			self.fireOnAcquire(waited)
+			return src, nil
+		}
+		ch := self.currentNotify()
+		if err = self.incAuto(); err != nil {
+			return nil, err
+		}
+		if err = self.waitForNotify(ctx, ch, timeout); err != nil {
+			return nil, err
+		}
+	}
+}
+
+//waitForNotify阻塞等待ch被唤醒/ctx取消/超时, 仅这段等待计入maxBlocking限制和waiting计数
+func (self *classic) waitForNotify(ctx context.Context, ch <-chan struct{}, timeout <-chan time.Time) error {
+	if self.maxBlocking > 0 {
+		if atomic.AddInt32(&self.blocking, 1) > self.maxBlocking {
+			atomic.AddInt32(&self.blocking, -1)
+			return ErrPoolOverloaded
+		}
+		defer atomic.AddInt32(&self.blocking, -1)
+	}
+	atomic.AddInt32(&self.waiting, 1)
+	defer atomic.AddInt32(&self.waiting, -1)
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return ErrAcquireTimeout
+	}
+}
+
+//currentNotify返回当前的唤醒信号channel
+func (self *classic) currentNotify() <-chan struct{} {
+	self.RLock()
+	defer self.RUnlock()
+	return self.notify
+}
+
+//按配置的顺序从空闲队列取出一个资源, ok为false表示队列为空
+func (self *classic) tryPop() (src Src, err error, ok bool) {
+	self.Lock()
+	defer self.Unlock()
+	if self.released {
+		return nil, releasedError, false
+	}
+	if len(self.idle) == 0 {
+		return nil, nil, false
+	}
+	var p *pooledSrc
+	if self.order == FIFO {
+		p = self.idle[0]
+		self.idle = self.idle[1:]
+	} else {
+		last := len(self.idle) - 1
+		p = self.idle[last]
+		self.idle = self.idle[:last]
+	}
+	return p.src, nil, true
+}
+
+//归还Acquire取出的资源, 若该资源已超过maxLifetime则在此处淘汰而非放回空闲队列
+func (self *classic) Put(src Src) error {
+	self.Lock()
+	if self.released {
+		self.Unlock()
+		return releasedError
+	}
+	if self.maxLifetime > 0 && time.Since(self.createdAt[src]) > self.maxLifetime {
+		delete(self.createdAt, src)
+		self.len--
+		self.notifyLocked()
+		self.Unlock()
+		src.Release()
+		atomic.AddUint64(&self.destroyed, 1)
+		self.fireOnEvict("lifetime")
+		self.fireOnDestroy(src, nil)
+		return nil
+	}
+	src.Reset()
+	self.idle = append(self.idle, &pooledSrc{src: src, createdAt: self.createdAt[src], lastUsedAt: time.Now()})
+	self.notifyLocked()
+	self.Unlock()
+	self.fireOnRelease()
+	return nil
+}
+
+//在已持有锁的情况下唤醒所有正在等待资源的协程
+func (self *classic) notifyLocked() {
+	close(self.notify)
+	self.notify = make(chan struct{})
+}
+
 //销毁资源池
 func (self *classic) Release() {
 	self.Lock()
@@ -106,11 +309,15 @@ func (self *classic) Release() {
 		return
 	}
 	self.released = true
-	for i := len(self.srcs); i >= 0; i-- {
-		(<-self.srcs).Release()
+	for _, p := range self.idle {
+		p.src.Release()
+		atomic.AddUint64(&self.destroyed, 1)
+		self.fireOnDestroy(p.src, nil)
 	}
-	close(self.srcs)
+	self.idle = nil
+	self.createdAt = nil
 	self.len = 0
+	self.notifyLocked()
 }
 
 //返回当前资源池剩余的数量
@@ -124,14 +331,35 @@ func (self *classic) Len() int {
 func (self *classic) gc() {
 	for !self.isReleased() {
 		self.Lock()
-		extra := len(self.srcs) - self.maxIdle
-		if extra > 0 {
-			self.len -= extra
-			for ; extra > 0; extra-- {
-				(<-self.srcs).Release()
+		idleCount := len(self.idle)
+		survivors := make([]*pooledSrc, 0, idleCount)
+		var evicted []*pooledSrc
+		var reasons []string
+		for _, p := range self.idle {
+			evict := self.eviction.ShouldEvict(p.src, p.createdAt, p.lastUsedAt, PoolStats{Len: self.len, Idle: idleCount})
+			reason := "policy"
+			if !evict && self.maxLifetime > 0 && time.Since(p.createdAt) > self.maxLifetime {
+				evict = true
+				reason = "lifetime"
+			}
+			if evict {
+				p.src.Release()
+				atomic.AddUint64(&self.destroyed, 1)
+				delete(self.createdAt, p.src)
+				self.len--
+				idleCount--
+				evicted = append(evicted, p)
+				reasons = append(reasons, reason)
+			} else {
+				survivors = append(survivors, p)
 			}
 		}
+		self.idle = survivors
 		self.Unlock()
+		for i, p := range evicted {
+			self.fireOnEvict(reasons[i])
+			self.fireOnDestroy(p.src, nil)
+		}
 		time.Sleep(self.gctime)
 	}
 }
@@ -139,36 +367,36 @@ func (self *classic) gc() {
 //资源扩容
 func (self *classic) incAuto() error {
 	self.Lock()
-	defer self.Unlock()
 	if self.len >= self.capacity {
+		self.Unlock()
 		return nil
 	}
 	src, err := self.factory()
 	if err != nil {
+		self.Unlock()
+		self.fireOnCreate(nil, err)
 		return err
 	}
-	self.srcs <- src
+	now := time.Now()
+	self.createdAt[src] = now
+	self.idle = append(self.idle, &pooledSrc{src: src, createdAt: now, lastUsedAt: now})
 	self.len++
+	atomic.AddUint64(&self.created, 1)
+	self.notifyLocked()
+	self.Unlock()
+	self.fireOnCreate(src, nil)
 	return nil
 }
 
 //删除资源
 func (self *classic) del(src Src) {
 	src.Release()
+	atomic.AddUint64(&self.destroyed, 1)
 	self.Lock()
+	delete(self.createdAt, src)
 	self.len--
 	self.Unlock()
-}
-
-//恢复/重置
-func (self *classic) recover(src Src) {
-	self.RLock()
-	defer self.RUnlock()
-	if self.released {
-		return
-	}
-	src.Reset()
-	self.srcs <- src
+	self.fireOnDestroy(src, nil)
 }
 
 //资源是否被释放
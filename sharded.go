@@ -0,0 +1,147 @@
+package pool
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+//分片资源池, 将请求打散到多个classic分片以避免单把锁成为瓶颈
+type shardedPool struct {
+	shards  []*classic
+	next    uint32
+	shardOf sync.Map //Src -> *classic, 记录资源归属的分片, 供Put找回
+}
+
+//WithShardCount... 设置ShardedPool的分片数量, 默认runtime.GOMAXPROCS(0)
+func WithShardCount(n int) Option {
+	return func(cfg *poolConfig) {
+		cfg.shardCount = n
+	}
+}
+
+//ShardedPool... 构建分片资源池, capacity/maxIdle会按分片数均摊
+//capacity/maxIdle应不小于分片数, 否则分片数会被降到capacity/maxIdle中的较小者, 避免出现容量为0的分片或maxIdle为0导致的空闲资源频繁destroy/recreate
+func ShardedPool(capacity, maxIdle int, factory Factory, opts ...Option) Pool {
+	cfg := newPoolConfig(opts...)
+	n := cfg.shardCount
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	if n > capacity {
+		n = capacity
+	}
+	if n > maxIdle {
+		n = maxIdle
+	}
+	if n < 1 {
+		n = 1
+	}
+	shards := make([]*classic, n)
+	for i := 0; i < n; i++ {
+		shardCapacity := capacity / n
+		if i < capacity%n {
+			shardCapacity++
+		}
+		shardMaxIdle := maxIdle / n
+		if i < maxIdle%n {
+			shardMaxIdle++
+		}
+		shards[i] = ClassicPool(shardCapacity, shardMaxIdle, factory, opts...).(*classic)
+	}
+	return &shardedPool{shards: shards}
+}
+
+//按原子自增计数轮询选取一个分片
+func (self *shardedPool) pick() *classic {
+	idx := atomic.AddUint32(&self.next, 1) % uint32(len(self.shards))
+	return self.shards[idx]
+}
+
+//调用资源池中的资源
+func (self *shardedPool) Call(callback func(Src) error) error {
+	return self.pick().Call(callback)
+}
+
+//带超时/取消的资源调用
+func (self *shardedPool) CallContext(ctx context.Context, callback func(Src) error) error {
+	return self.pick().CallContext(ctx, callback)
+}
+
+//获取一个可用资源, 需配合Put归还
+func (self *shardedPool) Acquire(ctx context.Context) (Src, error) {
+	shard := self.pick()
+	src, err := shard.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	self.shardOf.Store(src, shard)
+	return src, nil
+}
+
+//归还Acquire取出的资源, 会被路由回其所属的分片
+func (self *shardedPool) Put(src Src) error {
+	v, ok := self.shardOf.Load(src)
+	if !ok {
+		return releasedError
+	}
+	self.shardOf.Delete(src)
+	return v.(*classic).Put(src)
+}
+
+//销毁资源池
+func (self *shardedPool) Release() {
+	for _, shard := range self.shards {
+		shard.Release()
+	}
+	self.shardOf.Range(func(k, _ interface{}) bool {
+		self.shardOf.Delete(k)
+		return true
+	})
+}
+
+//返回所有分片资源数量之和
+func (self *shardedPool) Len() int {
+	total := 0
+	for _, shard := range self.shards {
+		total += shard.Len()
+	}
+	return total
+}
+
+//返回所有分片的运行状态汇总
+func (self *shardedPool) Stats() PoolStats {
+	var stats PoolStats
+	for i, shard := range self.shards {
+		s := shard.Stats()
+		stats.Len += s.Len
+		stats.Idle += s.Idle
+		stats.InUse += s.InUse
+		stats.Waiters += s.Waiters
+		stats.TotalCreated += s.TotalCreated
+		stats.TotalDestroyed += s.TotalDestroyed
+		if i == 0 {
+			stats.AcquireWaitHistogram = make([]HistogramBucket, len(s.AcquireWaitHistogram))
+			copy(stats.AcquireWaitHistogram, s.AcquireWaitHistogram)
+			continue
+		}
+		for j, b := range s.AcquireWaitHistogram {
+			stats.AcquireWaitHistogram[j].Count += b.Count
+		}
+	}
+	return stats
+}
+
+//依次巡检每个分片的空闲资源, 返回被淘汰的总数
+func (self *shardedPool) HealthCheck(ctx context.Context) (int, error) {
+	total := 0
+	for _, shard := range self.shards {
+		n, err := shard.HealthCheck(ctx)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
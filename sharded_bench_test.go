@@ -0,0 +1,42 @@
+package pool
+
+import (
+	"testing"
+)
+
+//noopSrc是benchmark用的最简资源, 不做任何实际工作
+type noopSrc struct{}
+
+func (noopSrc) IsUsable() bool { return true }
+func (noopSrc) Reset()         {}
+func (noopSrc) Release()       {}
+
+func noopFactory() (Src, error) {
+	return noopSrc{}, nil
+}
+
+//BenchmarkClassicPool_Concurrent 1k+并发调用下单锁classic的吞吐
+func BenchmarkClassicPool_Concurrent(b *testing.B) {
+	p := ClassicPool(256, 256, noopFactory)
+	defer p.Release()
+	b.SetParallelism(1024)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p.Call(func(Src) error { return nil })
+		}
+	})
+}
+
+//BenchmarkShardedPool_Concurrent 相同并发下ShardedPool按分片分摊锁竞争后的吞吐
+func BenchmarkShardedPool_Concurrent(b *testing.B) {
+	p := ShardedPool(256, 256, noopFactory)
+	defer p.Release()
+	b.SetParallelism(1024)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p.Call(func(Src) error { return nil })
+		}
+	})
+}